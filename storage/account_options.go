@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"github.com/Azure/azure-sdk-for-go/profiles/2018-03-01/storage/mgmt/storage"
+	"github.com/Azure/go-autorest/autorest/to"
+)
+
+// keySourceMicrosoftStorage is the only documented value for
+// Encryption.KeySource in this API version. It's a plain string here,
+// not a typed constant: the Azure.Storage/KeyVault key-source enum
+// (and the typed MinimumTLSVersion/NetworkRuleSet/EnableHTTPSTrafficOnly
+// properties) weren't added to AccountPropertiesCreateParameters until API
+// versions newer than the 2018-03-01 profile imported below.
+const keySourceMicrosoftStorage = "Microsoft.Storage"
+
+// StorageAccountOptions configures the properties of a storage account
+// created by CreateStorageAccount. Build one with NewStorageAccountOptions
+// (matching CreateStorageAccount's historical Standard_LRS defaults) or
+// NewSecureStorageAccountOptions (a secure-by-default preset), rather than
+// constructing this struct directly.
+type StorageAccountOptions struct {
+	SKU        storage.SkuName
+	Kind       storage.Kind
+	AccessTier storage.AccessTier
+
+	// Encryption configures encryption-at-rest. Leave nil to use the
+	// service's default (encryption enabled, Microsoft-managed keys).
+	Encryption *storage.Encryption
+}
+
+// StorageAccountOption customizes a StorageAccountOptions value.
+type StorageAccountOption func(*StorageAccountOptions)
+
+// NewStorageAccountOptions returns the options matching CreateStorageAccount's
+// historical defaults (Standard_LRS, no access tier, TLS, or network
+// restrictions), customized by any opts supplied.
+func NewStorageAccountOptions(opts ...StorageAccountOption) StorageAccountOptions {
+	options := StorageAccountOptions{
+		SKU: storage.StandardLRS,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// NewSecureStorageAccountOptions returns options for a secure-by-default
+// account: blob service encryption explicitly enabled with Microsoft-managed
+// keys. HTTPS-only traffic, a minimum TLS version, and network rule
+// restrictions aren't available through this package's pinned 2018-03-01
+// API profile (they were added to the storage mgmt API afterwards), so this
+// preset can't enforce them; set them through a newer client if you need
+// them. Any opts supplied are applied afterwards and can override
+// individual fields.
+func NewSecureStorageAccountOptions(opts ...StorageAccountOption) StorageAccountOptions {
+	secureDefaults := []StorageAccountOption{
+		WithSKU(storage.StandardLRS),
+		WithEncryption(&storage.Encryption{
+			Services: &storage.EncryptionServices{
+				Blob: &storage.EncryptionService{Enabled: to.BoolPtr(true)},
+			},
+			KeySource: to.StringPtr(keySourceMicrosoftStorage),
+		}),
+	}
+	return NewStorageAccountOptions(append(secureDefaults, opts...)...)
+}
+
+// WithSKU overrides the account's replication SKU.
+func WithSKU(sku storage.SkuName) StorageAccountOption {
+	return func(o *StorageAccountOptions) { o.SKU = sku }
+}
+
+// WithKind overrides the account kind (Storage or BlobStorage).
+func WithKind(kind storage.Kind) StorageAccountOption {
+	return func(o *StorageAccountOptions) { o.Kind = kind }
+}
+
+// WithAccessTier sets the default access tier for BlobStorage accounts.
+func WithAccessTier(tier storage.AccessTier) StorageAccountOption {
+	return func(o *StorageAccountOptions) { o.AccessTier = tier }
+}
+
+// WithEncryption sets the account's encryption-at-rest settings.
+func WithEncryption(encryption *storage.Encryption) StorageAccountOption {
+	return func(o *StorageAccountOptions) { o.Encryption = encryption }
+}
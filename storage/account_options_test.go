@@ -0,0 +1,35 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/profiles/2018-03-01/storage/mgmt/storage"
+)
+
+func TestNewStorageAccountOptionsDefaultsMatchHistoricalBehavior(t *testing.T) {
+	options := NewStorageAccountOptions()
+	if options.SKU != storage.StandardLRS {
+		t.Errorf("SKU = %v, want %v", options.SKU, storage.StandardLRS)
+	}
+	if options.Encryption != nil {
+		t.Error("Encryption should be nil by default, to match historical behavior")
+	}
+}
+
+func TestNewSecureStorageAccountOptionsIsSecureByDefault(t *testing.T) {
+	options := NewSecureStorageAccountOptions()
+	if options.Encryption == nil || options.Encryption.Services == nil || options.Encryption.Services.Blob == nil ||
+		options.Encryption.Services.Blob.Enabled == nil || !*options.Encryption.Services.Blob.Enabled {
+		t.Error("Encryption should explicitly enable blob service encryption")
+	}
+	if options.Encryption.KeySource == nil || *options.Encryption.KeySource != keySourceMicrosoftStorage {
+		t.Errorf("KeySource = %v, want %v", options.Encryption.KeySource, keySourceMicrosoftStorage)
+	}
+}
+
+func TestNewSecureStorageAccountOptionsAllowsOverrides(t *testing.T) {
+	options := NewSecureStorageAccountOptions(WithSKU(storage.StandardGRS))
+	if options.SKU != storage.StandardGRS {
+		t.Errorf("SKU = %v, want %v", options.SKU, storage.StandardGRS)
+	}
+}
@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Azure/go-autorest/autorest/azure"
+)
+
+// Config describes the Azure cloud a storage account lives in, so that a
+// single value can drive both the ARM client's base URI and the dataplane
+// URL instead of repeating endpoint details at every call site.
+type Config struct {
+	// Environment selects the Azure cloud (public, US Gov, China, Germany,
+	// or a custom profile for Azure Stack / sovereign clouds). The zero
+	// value is treated as azure.PublicCloud.
+	Environment azure.Environment
+
+	// BlobEndpoint overrides the dataplane URL entirely, e.g.
+	// "https://myaccount.blob.mystack.example.com". When set it takes
+	// precedence over Environment.StorageEndpointSuffix. Useful for Azure
+	// Stack or other deployments where the blob endpoint doesn't follow the
+	// standard "<account>.blob.<suffix>" shape.
+	BlobEndpoint string
+}
+
+// environment returns c.Environment, defaulting to azure.PublicCloud when
+// it's the zero value.
+func (c Config) environment() azure.Environment {
+	if c.Environment.ResourceManagerEndpoint == "" {
+		return azure.PublicCloud
+	}
+	return c.Environment
+}
+
+// resolveBlobEndpoint returns the base blob endpoint (no trailing slash) to
+// use for storageAccountName, honoring c.BlobEndpoint when set and falling
+// back to the environment's storage endpoint suffix otherwise.
+func (c Config) resolveBlobEndpoint(storageAccountName string) string {
+	if c.BlobEndpoint != "" {
+		return strings.TrimRight(c.BlobEndpoint, "/")
+	}
+	return fmt.Sprintf("https://%s.blob.%s", storageAccountName, c.environment().StorageEndpointSuffix)
+}
@@ -0,0 +1,34 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/Azure/go-autorest/autorest/azure"
+)
+
+func TestResolveBlobEndpointDefaultsToPublicCloud(t *testing.T) {
+	var config Config
+	got := config.resolveBlobEndpoint("myaccount")
+	want := "https://myaccount.blob.core.windows.net"
+	if got != want {
+		t.Errorf("resolveBlobEndpoint() = %q, want %q", got, want)
+	}
+}
+
+func TestResolveBlobEndpointHonorsEnvironmentSuffix(t *testing.T) {
+	config := Config{Environment: azure.USGovernmentCloud}
+	got := config.resolveBlobEndpoint("myaccount")
+	want := "https://myaccount.blob." + azure.USGovernmentCloud.StorageEndpointSuffix
+	if got != want {
+		t.Errorf("resolveBlobEndpoint() = %q, want %q", got, want)
+	}
+}
+
+func TestResolveBlobEndpointHonorsOverride(t *testing.T) {
+	config := Config{BlobEndpoint: "https://myaccount.blob.mystack.example.com/"}
+	got := config.resolveBlobEndpoint("myaccount")
+	want := "https://myaccount.blob.mystack.example.com"
+	if got != want {
+		t.Errorf("resolveBlobEndpoint() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"../iam"
+
+	"github.com/Azure/azure-sdk-for-go/profiles/2018-03-01/storage/mgmt/storage"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	"github.com/Azure/go-autorest/autorest/adal"
+)
+
+// storageResource is the OAuth resource identifier to request a token for
+// when talking to the blob dataplane, as opposed to the ARM resource
+// identifier used by iam.GetResourceManagementToken's other callers.
+const storageResource = "https://storage.azure.com/"
+
+// defaultTokenRefreshInterval is how often the background refresher renews
+// the AAD token ahead of its expiry.
+const defaultTokenRefreshInterval = 5 * time.Minute
+
+// TokenCredentialOptions configures AAD-based authentication for
+// GetDataplaneURLWithTokenCredential. Set ClientSecret or CertPath to
+// authenticate as a service principal; leave both empty to attempt MSI.
+type TokenCredentialOptions struct {
+	TenantID     string
+	ClientID     string
+	ClientSecret string
+	CertPath     string
+
+	// RefreshInterval controls how often the token is refreshed. Defaults to
+	// defaultTokenRefreshInterval when zero.
+	RefreshInterval time.Duration
+}
+
+// GetDataplaneURLWithTokenCredential returns a dataplane URL authenticated
+// via AAD (service principal, certificate, or MSI) instead of an account
+// key, reusing the same token-acquisition flow as
+// iam.GetResourceManagementToken. The fallback to GetDataplaneURL's
+// shared-key flow only covers the "AAD isn't configured for this call"
+// case (no client secret/cert and no MSI endpoint available); once a
+// service principal or MSI identity is actually used, a later failure
+// (a bad secret, an expired cert, a non-AAD-enabled account) is returned
+// to the caller rather than silently falling back, since downgrading to
+// shared key at that point would mask a real auth problem. Requires the
+// storage account to accept the package's pinned blob service version,
+// which supports Azure AD bearer tokens.
+func GetDataplaneURLWithTokenCredential(cntx context.Context, storageAccountsClient storage.AccountsClient, opts TokenCredentialOptions, config Config, storageAccountName, resourceGroupName, storageContainerName string) (containerURL azblob.ContainerURL, err error) {
+	spt, err := getStorageServicePrincipalToken(opts)
+	if err != nil {
+		if opts.ClientSecret != "" || opts.CertPath != "" {
+			return containerURL, fmt.Errorf("cannot create AAD token for storage: %v", err)
+		}
+		log.Printf("AAD authentication not configured (%v), falling back to shared key", err)
+		return GetDataplaneURL(cntx, storageAccountsClient, config, storageAccountName, resourceGroupName, storageContainerName)
+	}
+	if err = spt.Refresh(); err != nil {
+		return containerURL, fmt.Errorf("cannot acquire AAD token for storage: %v", err)
+	}
+
+	refreshInterval := opts.RefreshInterval
+	if refreshInterval <= 0 {
+		refreshInterval = defaultTokenRefreshInterval
+	}
+	credential := azblob.NewTokenCredential(spt.Token().AccessToken, func(tc azblob.TokenCredential) time.Duration {
+		if err := spt.Refresh(); err != nil {
+			log.Printf("cannot refresh AAD token for storage: %v", err)
+			return 0
+		}
+		tc.SetToken(spt.Token().AccessToken)
+		return refreshInterval
+	})
+	pipeline := azblob.NewPipeline(credential, azblob.PipelineOptions{})
+	URL, err := url.Parse(fmt.Sprintf("%s/%s", config.resolveBlobEndpoint(storageAccountName), storageContainerName))
+	if err != nil {
+		return containerURL, fmt.Errorf("cannot create container URL: %v", err)
+	}
+	containerURL = azblob.NewContainerURL(*URL, pipeline)
+	return containerURL, nil
+}
+
+// getStorageServicePrincipalToken acquires an AAD token scoped to the
+// storage resource, either for the service principal/certificate described
+// by opts, or for the VM's MSI when no client secret or certificate is set.
+// iam.GetResourceManagementToken returns the concrete *adal.ServicePrincipalToken
+// (not just the adal.OAuthTokenProvider interface GetStorageAccountsClient
+// hands to autorest.NewBearerAuthorizer), which is what lets
+// GetDataplaneURLWithTokenCredential call Refresh/Token on it.
+func getStorageServicePrincipalToken(opts TokenCredentialOptions) (*adal.ServicePrincipalToken, error) {
+	if opts.ClientSecret != "" || opts.CertPath != "" {
+		return iam.GetResourceManagementToken(opts.TenantID, opts.ClientID, opts.ClientSecret, storageResource, opts.CertPath)
+	}
+
+	msiEndpoint, err := adal.GetMSIVMEndpoint()
+	if err != nil {
+		return nil, fmt.Errorf("cannot determine MSI endpoint: %v", err)
+	}
+	if opts.ClientID != "" {
+		return adal.NewServicePrincipalTokenFromMSIWithUserAssignedID(msiEndpoint, storageResource, opts.ClientID)
+	}
+	return adal.NewServicePrincipalTokenFromMSI(msiEndpoint, storageResource)
+}
@@ -0,0 +1,174 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Azure/azure-storage-blob-go/azblob"
+)
+
+// BlobInfo describes a blob, or (in a non-recursive ListBlobs) a virtual
+// "subdirectory" prefix beneath the requested one.
+type BlobInfo struct {
+	Name          string
+	ContentLength int64
+	LastModified  time.Time
+
+	// IsPrefix is true when this entry is a virtual directory (a "/"-
+	// delimited prefix with no blob of its own at this level) rather than
+	// a blob. ContentLength and LastModified are zero-valued in that case.
+	// Always false when ListBlobs is called with recursive set.
+	IsPrefix bool
+}
+
+// ObjectStore is the object-store surface this package exposes on top of a
+// container, so alternative backends (local filesystem, S3, ...) can be
+// swapped in behind the same interface for testing.
+type ObjectStore interface {
+	ListBlobs(cntx context.Context, prefix string, recursive bool) ([]BlobInfo, error)
+	BlobExists(cntx context.Context, name string) (bool, error)
+	DeleteBlob(cntx context.Context, name string) error
+	DeleteContainer(cntx context.Context) error
+	DownloadBlob(cntx context.Context, name string, w io.Writer) error
+	CopyBlob(cntx context.Context, src, dst string) error
+}
+
+// ContainerObjectStore implements ObjectStore on top of an
+// azblob.ContainerURL.
+type ContainerObjectStore struct {
+	containerURL azblob.ContainerURL
+
+	// MaxResults caps how many blobs ListBlobs requests per page. Zero uses
+	// the service's own default.
+	MaxResults int32
+}
+
+var _ ObjectStore = (*ContainerObjectStore)(nil)
+
+// NewContainerObjectStore returns an ObjectStore backed by containerURL.
+func NewContainerObjectStore(containerURL azblob.ContainerURL) *ContainerObjectStore {
+	return &ContainerObjectStore{containerURL: containerURL}
+}
+
+// listBlobPages drives the explicit marker loop ListBlobs needs: the
+// ecosystem has known bugs around ListBlobsHierarchy paging dropping its
+// continuation token, so we page ourselves rather than trust a helper to
+// exhaust the listing. fetchPage is called once per page and must return
+// the blobs found along with the marker for the next page.
+func listBlobPages(fetchPage func(azblob.Marker) ([]BlobInfo, azblob.Marker, error)) ([]BlobInfo, error) {
+	var all []BlobInfo
+	for marker := (azblob.Marker{}); marker.NotDone(); {
+		page, next, err := fetchPage(marker)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, page...)
+		marker = next
+	}
+	return all, nil
+}
+
+// ListBlobs lists blobs whose name begins with prefix. With recursive set,
+// all matching blobs are returned regardless of "/" boundaries; otherwise
+// only the immediate level under prefix is returned, as with
+// ListBlobsHierarchySegment.
+func (s *ContainerObjectStore) ListBlobs(cntx context.Context, prefix string, recursive bool) ([]BlobInfo, error) {
+	options := azblob.ListBlobsSegmentOptions{Prefix: prefix, MaxResults: s.MaxResults}
+	return listBlobPages(func(marker azblob.Marker) ([]BlobInfo, azblob.Marker, error) {
+		if recursive {
+			resp, err := s.containerURL.ListBlobsFlatSegment(cntx, marker, options)
+			if err != nil {
+				return nil, azblob.Marker{}, fmt.Errorf("cannot list blobs: %v", err)
+			}
+			return blobItemsToInfo(resp.Segment.BlobItems), resp.NextMarker, nil
+		}
+		resp, err := s.containerURL.ListBlobsHierarchySegment(cntx, marker, "/", options)
+		if err != nil {
+			return nil, azblob.Marker{}, fmt.Errorf("cannot list blobs: %v", err)
+		}
+		infos := blobItemsToInfo(resp.Segment.BlobItems)
+		for _, prefix := range resp.Segment.BlobPrefixes {
+			infos = append(infos, BlobInfo{Name: prefix.Name, IsPrefix: true})
+		}
+		return infos, resp.NextMarker, nil
+	})
+}
+
+func blobItemsToInfo(items []azblob.BlobItem) []BlobInfo {
+	infos := make([]BlobInfo, len(items))
+	for i, item := range items {
+		infos[i] = BlobInfo{
+			Name:         item.Name,
+			LastModified: item.Properties.LastModified,
+		}
+		if item.Properties.ContentLength != nil {
+			infos[i].ContentLength = *item.Properties.ContentLength
+		}
+	}
+	return infos
+}
+
+// BlobExists reports whether name exists in the container.
+func (s *ContainerObjectStore) BlobExists(cntx context.Context, name string) (bool, error) {
+	_, err := s.containerURL.NewBlobURL(name).GetProperties(cntx, azblob.BlobAccessConditions{})
+	if err != nil {
+		if stgErr, ok := err.(azblob.StorageError); ok && stgErr.ServiceCode() == azblob.ServiceCodeBlobNotFound {
+			return false, nil
+		}
+		return false, fmt.Errorf("cannot check blob existence: %v", err)
+	}
+	return true, nil
+}
+
+// DeleteBlob deletes name and any of its snapshots.
+func (s *ContainerObjectStore) DeleteBlob(cntx context.Context, name string) error {
+	_, err := s.containerURL.NewBlobURL(name).Delete(cntx, azblob.DeleteSnapshotsOptionInclude, azblob.BlobAccessConditions{})
+	if err != nil {
+		return fmt.Errorf("cannot delete blob: %v", err)
+	}
+	return nil
+}
+
+// DeleteContainer deletes the backing container and everything in it.
+func (s *ContainerObjectStore) DeleteContainer(cntx context.Context) error {
+	_, err := s.containerURL.Delete(cntx, azblob.ContainerAccessConditions{})
+	if err != nil {
+		return fmt.Errorf("cannot delete container: %v", err)
+	}
+	return nil
+}
+
+// DownloadBlob downloads name into w.
+func (s *ContainerObjectStore) DownloadBlob(cntx context.Context, name string, w io.Writer) error {
+	return DownloadToWriter(cntx, s.containerURL, name, w, UploadOptions{})
+}
+
+// CopyBlob starts a server-side copy of src to dst within the same
+// container and waits for it to complete.
+func (s *ContainerObjectStore) CopyBlob(cntx context.Context, src, dst string) error {
+	srcURL := s.containerURL.NewBlobURL(src).URL()
+	dstBlobURL := s.containerURL.NewBlobURL(dst)
+	resp, err := dstBlobURL.StartCopyFromURL(cntx, srcURL, azblob.Metadata{}, azblob.ModifiedAccessConditions{}, azblob.BlobAccessConditions{})
+	if err != nil {
+		return fmt.Errorf("cannot copy blob: %v", err)
+	}
+	status := resp.CopyStatus()
+	for status == azblob.CopyStatusPending {
+		select {
+		case <-time.After(time.Second):
+		case <-cntx.Done():
+			return cntx.Err()
+		}
+		props, err := dstBlobURL.GetProperties(cntx, azblob.BlobAccessConditions{})
+		if err != nil {
+			return fmt.Errorf("cannot poll copy status: %v", err)
+		}
+		status = props.CopyStatus()
+	}
+	if status != azblob.CopyStatusSuccess {
+		return fmt.Errorf("copy from %v to %v ended with status %v", src, dst, status)
+	}
+	return nil
+}
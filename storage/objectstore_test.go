@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	"github.com/Azure/go-autorest/autorest/to"
+)
+
+func TestListBlobPagesFollowsContinuationMarker(t *testing.T) {
+	pages := [][]BlobInfo{
+		{{Name: "a"}, {Name: "b"}},
+		{{Name: "c"}},
+	}
+	markers := []azblob.Marker{
+		{Val: to.StringPtr("page-2")},
+		{Val: to.StringPtr("")},
+	}
+	calls := 0
+
+	got, err := listBlobPages(func(marker azblob.Marker) ([]BlobInfo, azblob.Marker, error) {
+		if calls >= len(pages) {
+			t.Fatalf("fetchPage called more times than expected: %d", calls+1)
+		}
+		page, next := pages[calls], markers[calls]
+		calls++
+		return page, next, nil
+	})
+	if err != nil {
+		t.Fatalf("listBlobPages() error = %v", err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("listBlobPages() returned %d blobs, want 3", len(got))
+	}
+	if calls != 2 {
+		t.Errorf("fetchPage called %d times, want 2", calls)
+	}
+}
+
+func TestListBlobPagesPropagatesError(t *testing.T) {
+	wantErr := fmt.Errorf("boom")
+	_, err := listBlobPages(func(marker azblob.Marker) ([]BlobInfo, azblob.Marker, error) {
+		return nil, azblob.Marker{}, wantErr
+	})
+	if err != wantErr {
+		t.Errorf("listBlobPages() error = %v, want %v", err, wantErr)
+	}
+}
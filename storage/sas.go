@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/profiles/2018-03-01/storage/mgmt/storage"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+)
+
+// ContainerSASOptions configures GenerateContainerSAS.
+type ContainerSASOptions struct {
+	// Permissions is the set of operations the SAS grants on the container.
+	Permissions azblob.ContainerSASPermissions
+
+	// Start and Expiry bound the SAS token's validity window.
+	Start, Expiry time.Time
+
+	// Version is the signed SAS version to use, e.g. "2015-04-05" for Azure
+	// Stack compatibility. Defaults to the SDK's current version when empty.
+	Version string
+}
+
+// GenerateContainerSAS produces a container-scoped service SAS token for
+// storageContainerName, signed with the storage account's key, so that key
+// never has to leave this process.
+func GenerateContainerSAS(cntx context.Context, storageAccountsClient storage.AccountsClient, resourceGroupName, storageAccountName, storageContainerName string, opts ContainerSASOptions) (sasToken string, err error) {
+	storageAccountKey, err := getStorageAccountKey(cntx, storageAccountsClient, resourceGroupName, storageAccountName)
+	if err != nil {
+		return "", fmt.Errorf("cannot get stroage account key: %v", err)
+	}
+	credential, err := azblob.NewSharedKeyCredential(storageAccountName, storageAccountKey)
+	if err != nil {
+		return "", fmt.Errorf("cannot create shared key credential: %v", err)
+	}
+	sasValues := azblob.BlobSASSignatureValues{
+		Version:       opts.Version,
+		StartTime:     opts.Start,
+		ExpiryTime:    opts.Expiry,
+		Permissions:   opts.Permissions.String(),
+		ContainerName: storageContainerName,
+	}
+	queryParams, err := sasValues.NewSASQueryParameters(credential)
+	if err != nil {
+		return "", fmt.Errorf("cannot generate container SAS: %v", err)
+	}
+	return queryParams.Encode(), nil
+}
+
+// GetDataplaneURLWithSAS returns a container URL authenticated with a
+// previously issued SAS token (see GenerateContainerSAS) instead of an
+// account key, so the URL can be handed to other processes without
+// granting them full account access.
+func GetDataplaneURLWithSAS(cntx context.Context, storageAccountName, storageContainerName, sasToken, storageEndpointSuffix string) (containerURL azblob.ContainerURL, err error) {
+	pipeline := azblob.NewPipeline(azblob.NewAnonymousCredential(), azblob.PipelineOptions{})
+	URL, err := url.Parse(fmt.Sprintf("https://%s.blob.%s/%s?%s", storageAccountName, storageEndpointSuffix, storageContainerName, sasToken))
+	if err != nil {
+		return containerURL, fmt.Errorf("cannot create container URL: %v", err)
+	}
+	containerURL = azblob.NewContainerURL(*URL, pipeline)
+	return containerURL, nil
+}
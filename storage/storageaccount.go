@@ -11,7 +11,7 @@ import (
 	"../iam"
 
 	"github.com/Azure/azure-sdk-for-go/profiles/2018-03-01/storage/mgmt/storage"
-	"github.com/Azure/azure-storage-blob-go/2016-05-31/azblob"
+	"github.com/Azure/azure-storage-blob-go/azblob"
 	"github.com/Azure/go-autorest/autorest"
 	"github.com/Azure/go-autorest/autorest/to"
 )
@@ -50,15 +50,21 @@ func UploadDataToContainer(cntx context.Context, containerURL azblob.ContainerUR
 	return err
 }
 
-// GetDataplaneURL returns dataplane URL
-func GetDataplaneURL(cntx context.Context, storageAccountsClient storage.AccountsClient, storageEndpointSuffix, storageAccountName, resourceGroupName, storageContainerName string) (containerURL azblob.ContainerURL, err error) {
+// GetDataplaneURL returns dataplane URL. config selects the Azure cloud (or
+// a BlobEndpoint override for Azure Stack / sovereign clouds); pass the zero
+// value to use the public cloud's default "<account>.blob.core.windows.net"
+// shape.
+func GetDataplaneURL(cntx context.Context, storageAccountsClient storage.AccountsClient, config Config, storageAccountName, resourceGroupName, storageContainerName string) (containerURL azblob.ContainerURL, err error) {
 	storageAccountKey, err := getStorageAccountKey(cntx, storageAccountsClient, resourceGroupName, storageAccountName)
 	if err != nil {
 		return containerURL, fmt.Errorf("cannot get stroage account key: %v", err)
 	}
-	credential := azblob.NewSharedKeyCredential(storageAccountName, storageAccountKey)
+	credential, err := azblob.NewSharedKeyCredential(storageAccountName, storageAccountKey)
+	if err != nil {
+		return containerURL, fmt.Errorf("cannot create shared key credential: %v", err)
+	}
 	pipeline := azblob.NewPipeline(credential, azblob.PipelineOptions{})
-	URL, err := url.Parse(fmt.Sprintf("https://%s.blob.%s/%s", storageAccountName, storageEndpointSuffix, storageContainerName))
+	URL, err := url.Parse(fmt.Sprintf("%s/%s", config.resolveBlobEndpoint(storageAccountName), storageContainerName))
 	if err != nil {
 		return containerURL, fmt.Errorf("cannot create container URL: %v", err)
 	}
@@ -66,19 +72,24 @@ func GetDataplaneURL(cntx context.Context, storageAccountsClient storage.Account
 	return containerURL, err
 }
 
-// GetStorageAccountsClient creates a new storage account client
-func GetStorageAccountsClient(tenantID, clientID, clientSecret, armEndpoint, certPath, subscriptionID string) storage.AccountsClient {
-	token, err := iam.GetResourceManagementToken(tenantID, clientID, clientSecret, armEndpoint, certPath)
+// GetStorageAccountsClient creates a new storage account client for the
+// Azure cloud described by config (the public cloud, a sovereign cloud, or
+// an Azure Stack profile), rather than always assuming the public cloud.
+func GetStorageAccountsClient(tenantID, clientID, clientSecret, certPath, subscriptionID string, config Config) storage.AccountsClient {
+	environment := config.environment()
+	token, err := iam.GetResourceManagementToken(tenantID, clientID, clientSecret, environment.ResourceManagerEndpoint, certPath)
 	if err != nil {
 		log.Fatal(fmt.Sprintf(errorPrefix, fmt.Sprintf("Cannot generate token. Error details: %v.", err)))
 	}
-	storageAccountsClient := storage.NewAccountsClientWithBaseURI(armEndpoint, subscriptionID)
+	storageAccountsClient := storage.NewAccountsClientWithBaseURI(environment.ResourceManagerEndpoint, subscriptionID)
 	storageAccountsClient.Authorizer = autorest.NewBearerAuthorizer(token)
 	return storageAccountsClient
 }
 
-// CreateStorageAccount creates a new storage account.
-func CreateStorageAccount(cntx context.Context, storageAccountsClient storage.AccountsClient, accountName, rgName, location string) (s storage.Account, err error) {
+// CreateStorageAccount creates a new storage account. Pass
+// NewStorageAccountOptions() for the historical Standard_LRS defaults, or
+// NewSecureStorageAccountOptions() to opt into a secure-by-default preset.
+func CreateStorageAccount(cntx context.Context, storageAccountsClient storage.AccountsClient, accountName, rgName, location string, options StorageAccountOptions) (s storage.Account, err error) {
 	result, err := storageAccountsClient.CheckNameAvailability(
 		cntx,
 		storage.AccountCheckNameAvailabilityParameters{
@@ -97,14 +108,18 @@ func CreateStorageAccount(cntx context.Context, storageAccountsClient storage.Ac
 		accountName,
 		storage.AccountCreateParameters{
 			Sku: &storage.Sku{
-				Name: storage.StandardLRS},
+				Name: options.SKU},
+			Kind:     options.Kind,
 			Location: to.StringPtr(location),
-			AccountPropertiesCreateParameters: &storage.AccountPropertiesCreateParameters{},
+			AccountPropertiesCreateParameters: &storage.AccountPropertiesCreateParameters{
+				AccessTier: options.AccessTier,
+				Encryption: options.Encryption,
+			},
 		})
 	if err != nil {
 		return s, fmt.Errorf(fmt.Sprintf(errorPrefix, err))
 	}
-	err = future.WaitForCompletion(cntx, storageAccountsClient.Client)
+	err = future.WaitForCompletionRef(cntx, storageAccountsClient.Client)
 	if err != nil {
 		return s, fmt.Errorf(fmt.Sprintf(errorPrefix, fmt.Sprintf("cannot get the storage account create future response: %v", err)))
 	}
@@ -0,0 +1,324 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-storage-blob-go/azblob"
+)
+
+const (
+	defaultUploadBlockSize   = 4 * 1024 * 1024
+	defaultUploadParallelism = 16
+	defaultRetryMaxTries     = 4
+	defaultRetryTryTimeout   = 1 * time.Minute
+	defaultRetryDelay        = 200 * time.Millisecond
+)
+
+// UploadOptions configures UploadReader, DownloadToWriter, and
+// UploadDirectory.
+type UploadOptions struct {
+	// BlockSize and Parallelism bound how a single blob is chunked and how
+	// many chunks transfer concurrently. Default to 4MB blocks, 16 at a
+	// time, matching UploadDataToContainer's historical behavior.
+	BlockSize   int64
+	Parallelism uint16
+
+	// Progress, if set, receives the cumulative number of bytes transferred
+	// so far, one line at a time, as the transfer proceeds.
+	Progress io.Writer
+
+	// RetryOptions controls how block stages and commits are retried on
+	// failure. Zero-valued fields fall back to conservative defaults.
+	// DownloadToWriter uses only MaxTries.
+	RetryOptions azblob.RetryOptions
+
+	// VerifyBlockContentMD5 asks the service to verify each uploaded
+	// block's content against an MD5 computed client-side before accepting
+	// it.
+	VerifyBlockContentMD5 bool
+
+	// SetBlobContentMD5 computes an MD5 over the whole stream and stores it
+	// in the blob's Content-MD5 header once the upload completes.
+	SetBlobContentMD5 bool
+}
+
+// withDefaults returns a copy of opts with zero-valued fields replaced by
+// sensible defaults.
+func (opts UploadOptions) withDefaults() UploadOptions {
+	if opts.BlockSize == 0 {
+		opts.BlockSize = defaultUploadBlockSize
+	}
+	if opts.Parallelism == 0 {
+		opts.Parallelism = defaultUploadParallelism
+	}
+	if opts.RetryOptions.MaxTries == 0 {
+		opts.RetryOptions.MaxTries = defaultRetryMaxTries
+	}
+	if opts.RetryOptions.TryTimeout == 0 {
+		opts.RetryOptions.TryTimeout = defaultRetryTryTimeout
+	}
+	if opts.RetryOptions.RetryDelay == 0 {
+		opts.RetryOptions.RetryDelay = defaultRetryDelay
+	}
+	return opts
+}
+
+// progressReader wraps an io.Reader, reporting the cumulative number of
+// bytes read to a sink as the caller consumes the stream.
+type progressReader struct {
+	r        io.Reader
+	progress io.Writer
+	total    int64
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.total += int64(n)
+		fmt.Fprintf(p.progress, "%d\n", p.total)
+	}
+	return n, err
+}
+
+// withRetry calls fn up to ro.MaxTries times, giving each attempt at most
+// ro.TryTimeout before it's abandoned, and waiting ro.RetryDelay between
+// attempts (doubling each time under RetryPolicyExponential, the package
+// default). It gives up early if cntx is done.
+func withRetry(cntx context.Context, ro azblob.RetryOptions, fn func(context.Context) error) (err error) {
+	delay := ro.RetryDelay
+	for attempt := int32(1); attempt <= ro.MaxTries; attempt++ {
+		tryCntx, cancel := context.WithTimeout(cntx, ro.TryTimeout)
+		err = fn(tryCntx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+		if attempt == ro.MaxTries {
+			break
+		}
+		select {
+		case <-time.After(delay):
+		case <-cntx.Done():
+			return cntx.Err()
+		}
+		if ro.Policy == azblob.RetryPolicyExponential {
+			delay *= 2
+		}
+	}
+	return err
+}
+
+// blockID returns a base64-encoded, order-preserving block ID for the n-th
+// block of a stream, suitable for CommitBlockList.
+func blockID(n int) string {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, uint64(n))
+	return base64.StdEncoding.EncodeToString(buf)
+}
+
+// UploadReader uploads data read from r to containerURL/blobName as a block
+// blob, streaming it in opts.BlockSize chunks with up to opts.Parallelism
+// uploaded concurrently. Unlike UploadDataToContainer, the caller doesn't
+// need to stage the data to a local file first, so r can be a pipe, an HTTP
+// request body, or a tar reader.
+func UploadReader(cntx context.Context, containerURL azblob.ContainerURL, blobName string, r io.Reader, opts UploadOptions) (err error) {
+	opts = opts.withDefaults()
+	blobURL := containerURL.NewBlockBlobURL(blobName)
+
+	var wholeBlobMD5 hash.Hash
+	if opts.SetBlobContentMD5 {
+		wholeBlobMD5 = md5.New()
+		r = io.TeeReader(r, wholeBlobMD5)
+	}
+	if opts.Progress != nil {
+		r = &progressReader{r: r, progress: opts.Progress}
+	}
+
+	var (
+		blockIDs []string
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		sem      = make(chan struct{}, opts.Parallelism)
+		stageErr error
+	)
+	buf := make([]byte, opts.BlockSize)
+	for blockNum := 0; ; blockNum++ {
+		n, readErr := io.ReadFull(r, buf)
+		if n > 0 {
+			id := blockID(blockNum)
+			data := make([]byte, n)
+			copy(data, buf[:n])
+
+			mu.Lock()
+			blockIDs = append(blockIDs, id)
+			mu.Unlock()
+
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(id string, data []byte) {
+				defer wg.Done()
+				defer func() { <-sem }()
+
+				var transactionalMD5 []byte
+				if opts.VerifyBlockContentMD5 {
+					sum := md5.Sum(data)
+					transactionalMD5 = sum[:]
+				}
+				err := withRetry(cntx, opts.RetryOptions, func(tryCntx context.Context) error {
+					_, err := blobURL.StageBlock(tryCntx, id, bytes.NewReader(data), azblob.LeaseAccessConditions{}, transactionalMD5)
+					return err
+				})
+				if err != nil {
+					mu.Lock()
+					if stageErr == nil {
+						stageErr = err
+					}
+					mu.Unlock()
+				}
+			}(id, data)
+		}
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		}
+		if readErr != nil {
+			return fmt.Errorf("cannot read upload stream: %v", readErr)
+		}
+	}
+	wg.Wait()
+	if stageErr != nil {
+		return fmt.Errorf("cannot stage block: %v", stageErr)
+	}
+
+	headers := azblob.BlobHTTPHeaders{}
+	if wholeBlobMD5 != nil {
+		headers.ContentMD5 = wholeBlobMD5.Sum(nil)
+	}
+	return withRetry(cntx, opts.RetryOptions, func(tryCntx context.Context) error {
+		_, err := blobURL.CommitBlockList(tryCntx, blockIDs, headers, azblob.Metadata{}, azblob.BlobAccessConditions{})
+		return err
+	})
+}
+
+// progressWriter wraps an io.Writer, reporting the cumulative number of
+// bytes written to a sink.
+type progressWriter struct {
+	w        io.Writer
+	progress io.Writer
+	total    int64
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	if n > 0 {
+		p.total += int64(n)
+		fmt.Fprintf(p.progress, "%d\n", p.total)
+	}
+	return n, err
+}
+
+// DownloadToWriter downloads blobName from containerURL into w, reporting
+// cumulative bytes transferred through opts.Progress when set. A body stream
+// that breaks is resumed up to opts.RetryOptions.MaxTries times; TryTimeout,
+// RetryDelay, and Policy are not applied to downloads, and the initial
+// request is retried only by containerURL's own pipeline.
+func DownloadToWriter(cntx context.Context, containerURL azblob.ContainerURL, blobName string, w io.Writer, opts UploadOptions) error {
+	opts = opts.withDefaults()
+	blobURL := containerURL.NewBlockBlobURL(blobName)
+
+	resp, err := blobURL.Download(cntx, 0, azblob.CountToEnd, azblob.BlobAccessConditions{}, false)
+	if err != nil {
+		return fmt.Errorf("cannot download blob: %v", err)
+	}
+	body := resp.Body(azblob.RetryReaderOptions{MaxRetryRequests: int(opts.RetryOptions.MaxTries)})
+	defer body.Close()
+
+	dst := w
+	if opts.Progress != nil {
+		dst = &progressWriter{w: w, progress: opts.Progress}
+	}
+	_, err = io.Copy(dst, body)
+	return err
+}
+
+// UploadDirectory walks localPath and uploads every non-directory entry to
+// containerURL, preserving the relative directory structure in each blob's
+// name, using a worker pool of opts.Parallelism goroutines so multiple
+// files transfer concurrently. Each file is uploaded through UploadReader
+// with its block-level Parallelism pinned to 1, so total concurrency stays
+// bounded by opts.Parallelism instead of growing quadratically; opts.Progress,
+// opts.RetryOptions, opts.VerifyBlockContentMD5, and opts.SetBlobContentMD5
+// apply to every file's upload.
+func UploadDirectory(cntx context.Context, containerURL azblob.ContainerURL, localPath string, opts UploadOptions) error {
+	opts = opts.withDefaults()
+	fileOpts := opts
+	fileOpts.Parallelism = 1
+
+	type file struct {
+		path, blobName string
+	}
+	var files []file
+	err := filepath.Walk(localPath, func(path string, info os.FileInfo, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if info.IsDir() {
+			return nil
+		}
+		relPath, relErr := filepath.Rel(localPath, path)
+		if relErr != nil {
+			return relErr
+		}
+		files = append(files, file{path: path, blobName: filepath.ToSlash(relPath)})
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("cannot walk %v: %v", localPath, err)
+	}
+
+	var (
+		wg        sync.WaitGroup
+		sem       = make(chan struct{}, opts.Parallelism)
+		mu        sync.Mutex
+		uploadErr error
+	)
+	for _, f := range files {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(f file) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			handle, openErr := os.Open(f.path)
+			if openErr != nil {
+				mu.Lock()
+				if uploadErr == nil {
+					uploadErr = fmt.Errorf("cannot read %v: %v", f.path, openErr)
+				}
+				mu.Unlock()
+				return
+			}
+			defer handle.Close()
+
+			if fileErr := UploadReader(cntx, containerURL, f.blobName, handle, fileOpts); fileErr != nil {
+				mu.Lock()
+				if uploadErr == nil {
+					uploadErr = fmt.Errorf("cannot upload %v: %v", f.path, fileErr)
+				}
+				mu.Unlock()
+			}
+		}(f)
+	}
+	wg.Wait()
+	return uploadErr
+}
@@ -0,0 +1,37 @@
+package storage
+
+import "testing"
+
+func TestUploadOptionsWithDefaults(t *testing.T) {
+	opts := UploadOptions{}.withDefaults()
+	if opts.BlockSize != defaultUploadBlockSize {
+		t.Errorf("BlockSize = %d, want %d", opts.BlockSize, defaultUploadBlockSize)
+	}
+	if opts.Parallelism != defaultUploadParallelism {
+		t.Errorf("Parallelism = %d, want %d", opts.Parallelism, defaultUploadParallelism)
+	}
+	if opts.RetryOptions.MaxTries != defaultRetryMaxTries {
+		t.Errorf("RetryOptions.MaxTries = %d, want %d", opts.RetryOptions.MaxTries, defaultRetryMaxTries)
+	}
+}
+
+func TestUploadOptionsWithDefaultsPreservesExplicitValues(t *testing.T) {
+	opts := UploadOptions{BlockSize: 1024, Parallelism: 4}.withDefaults()
+	if opts.BlockSize != 1024 {
+		t.Errorf("BlockSize = %d, want 1024", opts.BlockSize)
+	}
+	if opts.Parallelism != 4 {
+		t.Errorf("Parallelism = %d, want 4", opts.Parallelism)
+	}
+}
+
+func TestBlockIDIsUniquePerBlock(t *testing.T) {
+	ids := map[string]bool{}
+	for n := 0; n < 10; n++ {
+		id := blockID(n)
+		if ids[id] {
+			t.Fatalf("blockID(%d) collided with a previous block ID", n)
+		}
+		ids[id] = true
+	}
+}